@@ -1,11 +1,16 @@
 package segments
 
 import (
+	"crypto/md5"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jandedobbeleer/oh-my-posh/src/properties"
 	"github.com/jandedobbeleer/oh-my-posh/src/runtime"
@@ -20,6 +25,43 @@ type Owm struct {
 	URL         string
 	units       string
 	UnitIcon    string
+	Forecast    []OwmForecastDay
+
+	FeelsLike  int
+	TempMin    int
+	TempMax    int
+	Humidity   int
+	Pressure   int
+	WindSpeed  float64
+	WindDeg    int
+	WindDir    string
+	Clouds     int
+	Visibility int
+	Sunrise    time.Time
+	Sunset     time.Time
+	IsDay      bool
+
+	Description      string
+	ShortDescription string
+
+	AQI      int
+	AQILabel string
+	AQIColor string
+	PM25     float64
+	PM10     float64
+	O3       float64
+	NO2      float64
+	SO2      float64
+	CO       float64
+}
+
+// OwmForecastDay is a single aggregated day in the Owm.Forecast slice
+type OwmForecastDay struct {
+	Date     time.Time
+	Min      int
+	Max      int
+	Weather  string
+	PrecipMM float64
 }
 
 const (
@@ -33,10 +75,44 @@ const (
 	Longitude properties.Property = "lon"
 	// Units openweathermap units
 	Units properties.Property = "units"
+	// Days the number of forecast days to aggregate
+	Days properties.Property = "days"
+	// Provider selects the weather backend to query
+	Provider properties.Property = "provider"
+	// Lang the language OpenWeatherMap should translate weather descriptions into
+	Lang properties.Property = "lang"
+	// Zip the zip/postal code to resolve via the geocoding API, e.g. "90210,US"
+	Zip properties.Property = "zip"
+	// AQIEnabled enables fetching the air pollution sub-segment alongside the weather
+	AQIEnabled properties.Property = "aqi"
 	// CacheKeyResponse key used when caching the response
 	CacheKeyResponse string = "owm_response"
 	// CacheKeyURL key used when caching the url responsible for the response
 	CacheKeyURL string = "owm_url"
+	// CacheKeyForecastResponse key used when caching the forecast response
+	CacheKeyForecastResponse string = "owm_forecast_response"
+	// CacheKeyForecastURL key used when caching the url responsible for the forecast response
+	CacheKeyForecastURL string = "owm_forecast_url"
+	// CacheKeyGeoPrefix prefix for the cache key a resolved lat/lon is stored under
+	CacheKeyGeoPrefix string = "owm_geo_"
+	// GeoCacheTimeout how long, in minutes, a geocoding resolution is cached;
+	// lat/lon for a given location rarely changes, so this is deliberately
+	// long and independent of the short weather CacheTimeout
+	GeoCacheTimeout int = 60 * 24 * 30
+	// CacheKeyAirResponse key used when caching the air pollution response
+	CacheKeyAirResponse string = "owm_air_response"
+	// CacheKeyMetNorwayResponse key used when caching the MET Norway response
+	CacheKeyMetNorwayResponse string = "owm_metnorway_response"
+	// CacheKeyMetNorwayURL key used when caching the url responsible for the MET Norway response
+	CacheKeyMetNorwayURL string = "owm_metnorway_url"
+	// CacheKeyOpenMeteoResponse key used when caching the Open-Meteo response
+	CacheKeyOpenMeteoResponse string = "owm_openmeteo_response"
+	// CacheKeyOpenMeteoURL key used when caching the url responsible for the Open-Meteo response
+	CacheKeyOpenMeteoURL string = "owm_openmeteo_url"
+	// CacheKeyWttrInResponse key used when caching the wttr.in response
+	CacheKeyWttrInResponse string = "owm_wttrin_response"
+	// CacheKeyWttrInURL key used when caching the url responsible for the wttr.in response
+	CacheKeyWttrInURL string = "owm_wttrin_url"
 	// Environmental variable to dynamically set the Open Map API key
 	PoshOWMAPIKey string = "POSH_OWM_API_KEY"
 	// Environmental variable to dynamically set the location string
@@ -45,6 +121,19 @@ const (
 	PoshOWMLatKey string = "POSH_OWM_LAT"
 	// Environmental variable to dynamically set the longitude
 	PoshOWMLonKey string = "POSH_OWM_LON"
+	// Environmental variable to dynamically set the description language
+	PoshOWMLangKey string = "POSH_OWM_LANG"
+	// Environmental variable to dynamically set the zip/postal code
+	PoshOWMZipKey string = "POSH_OWM_ZIP"
+)
+
+// supported values for the provider property; owm is the default and the only
+// one that requires an api_key, the rest are keyless fallbacks
+const (
+	providerOWM       string = "owm"
+	providerMetNorway string = "met-norway"
+	providerOpenMeteo string = "open-meteo"
+	providerWttrIn    string = "wttr-in"
 )
 
 type weather struct {
@@ -53,12 +142,80 @@ type weather struct {
 	TypeID           string `json:"icon"`
 }
 type temperature struct {
-	Value float64 `json:"temp"`
+	Value     float64 `json:"temp"`
+	FeelsLike float64 `json:"feels_like"`
+	TempMin   float64 `json:"temp_min"`
+	TempMax   float64 `json:"temp_max"`
+	Pressure  int     `json:"pressure"`
+	Humidity  int     `json:"humidity"`
 }
 
 type owmDataResponse struct {
 	Data        []weather `json:"weather"`
 	temperature `json:"main"`
+	Wind        struct {
+		Speed float64 `json:"speed"`
+		Deg   int     `json:"deg"`
+	} `json:"wind"`
+	Clouds struct {
+		All int `json:"all"`
+	} `json:"clouds"`
+	Visibility int `json:"visibility"`
+	Sys        struct {
+		Sunrise int64 `json:"sunrise"`
+		Sunset  int64 `json:"sunset"`
+	} `json:"sys"`
+	Timezone int `json:"timezone"`
+}
+
+type forecastEntry struct {
+	Dt   int64     `json:"dt"`
+	Data []weather `json:"weather"`
+	Main struct {
+		TempMin float64 `json:"temp_min"`
+		TempMax float64 `json:"temp_max"`
+	} `json:"main"`
+	Rain struct {
+		ThreeHour float64 `json:"3h"`
+	} `json:"rain"`
+	Snow struct {
+		ThreeHour float64 `json:"3h"`
+	} `json:"snow"`
+}
+
+type owmForecastResponse struct {
+	List []forecastEntry `json:"list"`
+	City struct {
+		Timezone int `json:"timezone"`
+	} `json:"city"`
+}
+
+// WeatherObservation is the provider-agnostic current weather reading a
+// WeatherProvider resolves; Owm.setStatus maps it onto the segment's
+// template fields regardless of which backend produced it.
+type WeatherObservation struct {
+	Temperature      float64
+	FeelsLike        float64
+	TempMin          float64
+	TempMax          float64
+	Humidity         int
+	Pressure         int
+	WindSpeed        float64
+	WindDeg          int
+	Clouds           int
+	Visibility       int
+	Sunrise          time.Time
+	Sunset           time.Time
+	IsDay            bool
+	Icon             string
+	Description      string
+	ShortDescription string
+	URL              string
+}
+
+// WeatherProvider fetches the current weather observation for a configured location
+type WeatherProvider interface {
+	Fetch() (*WeatherObservation, error)
 }
 
 func (d *Owm) Enabled() bool {
@@ -69,6 +226,19 @@ func (d *Owm) Enabled() bool {
 		return false
 	}
 
+	days := d.props.GetInt(Days, 0)
+	if days > 0 {
+		if err := d.setForecast(days); err != nil {
+			d.env.Error(err)
+		}
+	}
+
+	if d.props.GetBool(AQIEnabled, false) {
+		if err := d.setAirQuality(); err != nil {
+			d.env.Error(err)
+		}
+	}
+
 	return true
 }
 
@@ -76,138 +246,895 @@ func (d *Owm) Template() string {
 	return " {{ .Weather }} ({{ .Temperature }}{{ .UnitIcon }}) "
 }
 
-func (d *Owm) getPropOrEnvVar(envKey, defaultValue string, propKeyOptions ...properties.Property) string {
-	v := properties.OneOf(d.props, defaultValue, propKeyOptions...)
+func getPropOrEnvVar(props properties.Properties, env runtime.Environment, envKey, defaultValue string, propKeyOptions ...properties.Property) string {
+	v := properties.OneOf(props, defaultValue, propKeyOptions...)
 	if len(v) == 0 {
-		v = d.env.Getenv(envKey)
+		v = env.Getenv(envKey)
 	}
 	return v
 }
 
-func (d *Owm) getResult() (*owmDataResponse, error) {
-	cacheTimeout := d.props.GetInt(properties.CacheTimeout, properties.DefaultCacheTimeout)
+func (d *Owm) getPropOrEnvVar(envKey, defaultValue string, propKeyOptions ...properties.Property) string {
+	return getPropOrEnvVar(d.props, d.env, envKey, defaultValue, propKeyOptions...)
+}
+
+// getProvider resolves the configured WeatherProvider. When owm is selected but
+// no api_key is available, it falls back to a keyless provider instead of
+// erroring so the segment still renders something useful out of the box.
+func (d *Owm) getProvider() WeatherProvider {
+	name := d.props.GetString(Provider, providerOWM)
+
+	if name == providerOWM {
+		apikey := d.getPropOrEnvVar(PoshOWMAPIKey, "", APIKey, "apiKey")
+		if len(apikey) == 0 {
+			name = providerWttrIn
+		}
+	}
+
+	switch name {
+	case providerMetNorway:
+		return &metNorwayProvider{props: d.props, env: d.env}
+	case providerOpenMeteo:
+		return &openMeteoProvider{props: d.props, env: d.env}
+	case providerWttrIn:
+		return &wttrInProvider{props: d.props, env: d.env}
+	default:
+		return &owmProvider{props: d.props, env: d.env}
+	}
+}
+
+type owmProvider struct {
+	props properties.Properties
+	env   runtime.Environment
+}
+
+func (p *owmProvider) Fetch() (*WeatherObservation, error) {
+	cacheTimeout := p.props.GetInt(properties.CacheTimeout, properties.DefaultCacheTimeout)
 	response := new(owmDataResponse)
+	requestURL := ""
 
 	if cacheTimeout > 0 {
-		val, found := d.env.Cache().Get(CacheKeyResponse)
+		val, found := p.env.Cache().Get(CacheKeyResponse)
 		if found {
-			err := json.Unmarshal([]byte(val), response)
-			if err != nil {
+			if err := json.Unmarshal([]byte(val), response); err != nil {
 				return nil, err
 			}
 
-			d.URL, _ = d.env.Cache().Get(CacheKeyURL)
-			return response, nil
+			requestURL, _ = p.env.Cache().Get(CacheKeyURL)
+			return owmObservation(response, requestURL), nil
 		}
 	}
 
-	apikey := d.getPropOrEnvVar(PoshOWMAPIKey, ".", APIKey, "apiKey")
+	apikey := getPropOrEnvVar(p.props, p.env, PoshOWMAPIKey, "", APIKey, "apiKey")
 	if len(apikey) == 0 {
 		return nil, errors.New("no api key found")
 	}
 
-	units := d.props.GetString(Units, "standard")
-	httpTimeout := d.props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
+	units := p.props.GetString(Units, "standard")
+	httpTimeout := p.props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
 
-	location := d.getPropOrEnvVar(PoshOWMLocationKey, "De Bilt,NL", Location)
-	// location = url.QueryEscape(location)
+	lat, lon, err := resolveCoordinates(p.props, p.env, apikey)
+	if err != nil {
+		return nil, err
+	}
 
-	// Use different URLs depending on if a location or lat/lon were passed
-	if len(location) > 0 {
-		location = url.QueryEscape(location)
-		d.URL = fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?q=%s&units=%s&appid=%s", location, units, apikey)
-	} else {
-		lat := d.getPropOrEnvVar(PoshOWMLatKey, "0", Latitude)
-		lat = url.QueryEscape(lat)
-		lon := d.getPropOrEnvVar(PoshOWMLonKey, "0", Longitude)
-		lon = url.QueryEscape(lon)
-		d.URL = fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?lat=%s&lon=%s&units=%s&appid=%s", lat, lon, units, apikey)
+	requestURL = fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?lat=%s&lon=%s&units=%s&appid=%s", lat, lon, units, apikey)
+
+	lang := getPropOrEnvVar(p.props, p.env, PoshOWMLangKey, "", Lang)
+	if len(lang) > 0 {
+		requestURL += fmt.Sprintf("&lang=%s", url.QueryEscape(lang))
 	}
 
-	body, err := d.env.HTTPRequest(d.URL, nil, httpTimeout)
+	body, err := p.env.HTTPRequest(requestURL, nil, httpTimeout)
 	if err != nil {
-		return new(owmDataResponse), err
+		return nil, err
 	}
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return new(owmDataResponse), err
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Data) == 0 {
+		return nil, errors.New("No data found")
 	}
 
 	if cacheTimeout > 0 {
 		// persist new forecasts in cache
-		d.env.Cache().Set(CacheKeyResponse, string(body), cacheTimeout)
-		d.env.Cache().Set(CacheKeyURL, d.URL, cacheTimeout)
+		p.env.Cache().Set(CacheKeyResponse, string(body), cacheTimeout)
+		p.env.Cache().Set(CacheKeyURL, requestURL, cacheTimeout)
 	}
-	return response, nil
+
+	return owmObservation(response, requestURL), nil
 }
 
-func (d *Owm) setStatus() error {
-	units := d.props.GetString(Units, "standard")
+type geoDirectResult struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type geoZipResult struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// resolveCoordinates turns the configured location or zip into a lat/lon pair
+// via the OWM geocoding API, matching `q=` to `/geo/1.0/direct` and `zip` to
+// `/geo/1.0/zip`. Resolutions are cached under GeoCacheTimeout, separate from
+// the short-lived weather cache, since a location's coordinates don't change.
+// Explicit lat/lon properties bypass geocoding when neither location nor zip is
+// set; when either is set, lat/lon are ignored. "De Bilt,NL" is only used as a
+// last resort, when neither location/zip nor lat/lon are configured.
+// Shared by the current weather, forecast and air quality lookups, all of
+// which need the same coordinates for the same configured location.
+func resolveCoordinates(props properties.Properties, env runtime.Environment, apikey string) (lat string, lon string, err error) {
+	location := getPropOrEnvVar(props, env, PoshOWMLocationKey, "", Location)
+	zip := getPropOrEnvVar(props, env, PoshOWMZipKey, "", Zip)
+
+	if len(location) == 0 && len(zip) == 0 {
+		latProp := getPropOrEnvVar(props, env, PoshOWMLatKey, "", Latitude)
+		lonProp := getPropOrEnvVar(props, env, PoshOWMLonKey, "", Longitude)
+		if len(latProp) > 0 || len(lonProp) > 0 {
+			if len(latProp) == 0 {
+				latProp = "0"
+			}
+			if len(lonProp) == 0 {
+				lonProp = "0"
+			}
+			return latProp, lonProp, nil
+		}
+
+		location = "De Bilt,NL"
+	}
+
+	query := location
+	geoURL := fmt.Sprintf("https://api.openweathermap.org/geo/1.0/direct?q=%s&limit=1&appid=%s", url.QueryEscape(location), apikey)
+	if len(zip) > 0 {
+		query = zip
+		geoURL = fmt.Sprintf("https://api.openweathermap.org/geo/1.0/zip?zip=%s&appid=%s", url.QueryEscape(zip), apikey)
+	}
 
-	q, err := d.getResult()
+	cacheKey := fmt.Sprintf("%s%x", CacheKeyGeoPrefix, md5.Sum([]byte(query)))
+	if val, found := env.Cache().Get(cacheKey); found {
+		if parts := strings.SplitN(val, ",", 2); len(parts) == 2 {
+			return parts[0], parts[1], nil
+		}
+	}
+
+	httpTimeout := props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
+	body, err := env.HTTPRequest(geoURL, nil, httpTimeout)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
-	if len(q.Data) == 0 {
-		return errors.New("No data found")
+	if len(zip) > 0 {
+		result := new(geoZipResult)
+		if err := json.Unmarshal(body, result); err != nil {
+			return "", "", err
+		}
+		if result.Lat == 0 && result.Lon == 0 {
+			return "", "", errors.New("no geocoding results found")
+		}
+		lat = strconv.FormatFloat(result.Lat, 'f', -1, 64)
+		lon = strconv.FormatFloat(result.Lon, 'f', -1, 64)
+	} else {
+		var results []geoDirectResult
+		if err := json.Unmarshal(body, &results); err != nil {
+			return "", "", err
+		}
+		if len(results) == 0 {
+			return "", "", errors.New("no geocoding results found")
+		}
+		lat = strconv.FormatFloat(results[0].Lat, 'f', -1, 64)
+		lon = strconv.FormatFloat(results[0].Lon, 'f', -1, 64)
 	}
 
-	id := q.Data[0].TypeID
+	env.Cache().Set(cacheKey, fmt.Sprintf("%s,%s", lat, lon), GeoCacheTimeout)
+	return lat, lon, nil
+}
 
-	d.Temperature = int(math.Round(q.temperature.Value))
+func owmObservation(q *owmDataResponse, requestURL string) *WeatherObservation {
 	icon := ""
+	description := ""
+	shortDescription := ""
+	if len(q.Data) > 0 {
+		icon = owmIcon(q.Data[0].TypeID)
+		description = q.Data[0].Description
+		shortDescription = q.Data[0].ShortDescription
+	}
+
+	zone := time.FixedZone("", q.Timezone)
+	sunrise := time.Unix(q.Sys.Sunrise, 0)
+	sunset := time.Unix(q.Sys.Sunset, 0)
+	now := time.Now()
+
+	return &WeatherObservation{
+		Temperature:      q.temperature.Value,
+		FeelsLike:        q.temperature.FeelsLike,
+		TempMin:          q.temperature.TempMin,
+		TempMax:          q.temperature.TempMax,
+		Humidity:         q.temperature.Humidity,
+		Pressure:         q.temperature.Pressure,
+		WindSpeed:        q.Wind.Speed,
+		WindDeg:          q.Wind.Deg,
+		Clouds:           q.Clouds.All,
+		Visibility:       q.Visibility,
+		Sunrise:          sunrise.In(zone),
+		Sunset:           sunset.In(zone),
+		IsDay:            now.After(sunrise) && now.Before(sunset),
+		Icon:             icon,
+		Description:      description,
+		ShortDescription: shortDescription,
+		URL:              requestURL,
+	}
+}
+
+// metNorwayProvider queries the keyless MET Norway Locationforecast API; it
+// only supports coordinate-based lookups, so lat/lon must be configured.
+type metNorwayProvider struct {
+	props properties.Properties
+	env   runtime.Environment
+}
+
+type metNorwayResponse struct {
+	Properties struct {
+		Timeseries []struct {
+			Data struct {
+				Instant struct {
+					Details struct {
+						AirTemperature        float64 `json:"air_temperature"`
+						RelativeHumidity      float64 `json:"relative_humidity"`
+						WindSpeed             float64 `json:"wind_speed"`
+						WindFromDirection     float64 `json:"wind_from_direction"`
+						AirPressureAtSeaLevel float64 `json:"air_pressure_at_sea_level"`
+						CloudAreaFraction     float64 `json:"cloud_area_fraction"`
+					} `json:"details"`
+				} `json:"instant"`
+				Next1Hours struct {
+					Summary struct {
+						SymbolCode string `json:"symbol_code"`
+					} `json:"summary"`
+				} `json:"next_1_hours"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+func (p *metNorwayProvider) Fetch() (*WeatherObservation, error) {
+	cacheTimeout := p.props.GetInt(properties.CacheTimeout, properties.DefaultCacheTimeout)
+	response := new(metNorwayResponse)
+
+	if cacheTimeout > 0 {
+		val, found := p.env.Cache().Get(CacheKeyMetNorwayResponse)
+		if found {
+			if err := json.Unmarshal([]byte(val), response); err != nil {
+				return nil, err
+			}
+
+			requestURL, _ := p.env.Cache().Get(CacheKeyMetNorwayURL)
+			return metNorwayObservation(response, requestURL)
+		}
+	}
+
+	httpTimeout := p.props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
+
+	lat := getPropOrEnvVar(p.props, p.env, PoshOWMLatKey, "0", Latitude)
+	lon := getPropOrEnvVar(p.props, p.env, PoshOWMLonKey, "0", Longitude)
+	requestURL := fmt.Sprintf("https://api.met.no/weatherapi/locationforecast/2.0/compact?lat=%s&lon=%s", url.QueryEscape(lat), url.QueryEscape(lon))
+
+	// MET Norway requires an identifying User-Agent on every request; the
+	// runtime's HTTP client sets one for all outbound requests by default.
+	body, err := p.env.HTTPRequest(requestURL, nil, httpTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Properties.Timeseries) == 0 {
+		return nil, errors.New("No data found")
+	}
+
+	if cacheTimeout > 0 {
+		p.env.Cache().Set(CacheKeyMetNorwayResponse, string(body), cacheTimeout)
+		p.env.Cache().Set(CacheKeyMetNorwayURL, requestURL, cacheTimeout)
+	}
+
+	return metNorwayObservation(response, requestURL)
+}
+
+func metNorwayObservation(response *metNorwayResponse, requestURL string) (*WeatherObservation, error) {
+	if len(response.Properties.Timeseries) == 0 {
+		return nil, errors.New("No data found")
+	}
+
+	details := response.Properties.Timeseries[0].Data.Instant.Details
+	symbol := response.Properties.Timeseries[0].Data.Next1Hours.Summary.SymbolCode
+
+	return &WeatherObservation{
+		Temperature: details.AirTemperature,
+		Humidity:    int(math.Round(details.RelativeHumidity)),
+		Pressure:    int(math.Round(details.AirPressureAtSeaLevel)),
+		WindSpeed:   details.WindSpeed,
+		WindDeg:     int(math.Round(details.WindFromDirection)),
+		Clouds:      int(math.Round(details.CloudAreaFraction)),
+		Icon:        metNorwayIcon(symbol),
+		URL:         requestURL,
+	}, nil
+}
+
+// openMeteoProvider queries the keyless Open-Meteo API; like MET Norway it
+// only supports coordinate-based lookups.
+type openMeteoProvider struct {
+	props properties.Properties
+	env   runtime.Environment
+}
+
+type openMeteoResponse struct {
+	CurrentWeather struct {
+		Time          string  `json:"time"`
+		Temperature   float64 `json:"temperature"`
+		WindSpeed     float64 `json:"windspeed"`
+		WindDirection float64 `json:"winddirection"`
+		WeatherCode   int     `json:"weathercode"`
+	} `json:"current_weather"`
+}
+
+func (p *openMeteoProvider) Fetch() (*WeatherObservation, error) {
+	cacheTimeout := p.props.GetInt(properties.CacheTimeout, properties.DefaultCacheTimeout)
+	response := new(openMeteoResponse)
+
+	if cacheTimeout > 0 {
+		val, found := p.env.Cache().Get(CacheKeyOpenMeteoResponse)
+		if found {
+			if err := json.Unmarshal([]byte(val), response); err != nil {
+				return nil, err
+			}
+
+			requestURL, _ := p.env.Cache().Get(CacheKeyOpenMeteoURL)
+			return openMeteoObservation(response, requestURL)
+		}
+	}
+
+	httpTimeout := p.props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
+
+	lat := getPropOrEnvVar(p.props, p.env, PoshOWMLatKey, "0", Latitude)
+	lon := getPropOrEnvVar(p.props, p.env, PoshOWMLonKey, "0", Longitude)
+	requestURL := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%s&longitude=%s&current_weather=true", url.QueryEscape(lat), url.QueryEscape(lon))
+
+	body, err := p.env.HTTPRequest(requestURL, nil, httpTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+
+	if len(response.CurrentWeather.Time) == 0 {
+		return nil, errors.New("No data found")
+	}
+
+	if cacheTimeout > 0 {
+		p.env.Cache().Set(CacheKeyOpenMeteoResponse, string(body), cacheTimeout)
+		p.env.Cache().Set(CacheKeyOpenMeteoURL, requestURL, cacheTimeout)
+	}
+
+	return openMeteoObservation(response, requestURL)
+}
+
+func openMeteoObservation(response *openMeteoResponse, requestURL string) (*WeatherObservation, error) {
+	if len(response.CurrentWeather.Time) == 0 {
+		return nil, errors.New("No data found")
+	}
+
+	return &WeatherObservation{
+		Temperature: response.CurrentWeather.Temperature,
+		WindSpeed:   response.CurrentWeather.WindSpeed,
+		WindDeg:     int(math.Round(response.CurrentWeather.WindDirection)),
+		Icon:        openMeteoIcon(response.CurrentWeather.WeatherCode),
+		URL:         requestURL,
+	}, nil
+}
+
+// wttrInProvider queries the keyless wttr.in JSON API; unlike the other
+// keyless providers it accepts a plain location string, matching the
+// existing `location` property.
+type wttrInProvider struct {
+	props properties.Properties
+	env   runtime.Environment
+}
+
+type wttrInResponse struct {
+	CurrentCondition []struct {
+		TempC       string `json:"temp_C"`
+		FeelsLikeC  string `json:"FeelsLikeC"`
+		Humidity    string `json:"humidity"`
+		Pressure    string `json:"pressure"`
+		CloudCover  string `json:"cloudcover"`
+		Visibility  string `json:"visibility"`
+		WindspeedKm string `json:"windspeedKmph"`
+		WinddirDeg  string `json:"winddirDegree"`
+		WeatherCode string `json:"weatherCode"`
+	} `json:"current_condition"`
+}
+
+func (p *wttrInProvider) Fetch() (*WeatherObservation, error) {
+	cacheTimeout := p.props.GetInt(properties.CacheTimeout, properties.DefaultCacheTimeout)
+	response := new(wttrInResponse)
+
+	if cacheTimeout > 0 {
+		val, found := p.env.Cache().Get(CacheKeyWttrInResponse)
+		if found {
+			if err := json.Unmarshal([]byte(val), response); err != nil {
+				return nil, err
+			}
+
+			requestURL, _ := p.env.Cache().Get(CacheKeyWttrInURL)
+			return wttrInObservation(response, requestURL)
+		}
+	}
+
+	httpTimeout := p.props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
+
+	location := getPropOrEnvVar(p.props, p.env, PoshOWMLocationKey, "De Bilt,NL", Location)
+	requestURL := fmt.Sprintf("https://wttr.in/%s?format=j1", url.QueryEscape(location))
+
+	body, err := p.env.HTTPRequest(requestURL, nil, httpTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+
+	if len(response.CurrentCondition) == 0 {
+		return nil, errors.New("No data found")
+	}
+
+	if cacheTimeout > 0 {
+		p.env.Cache().Set(CacheKeyWttrInResponse, string(body), cacheTimeout)
+		p.env.Cache().Set(CacheKeyWttrInURL, requestURL, cacheTimeout)
+	}
+
+	return wttrInObservation(response, requestURL)
+}
+
+func wttrInObservation(response *wttrInResponse, requestURL string) (*WeatherObservation, error) {
+	if len(response.CurrentCondition) == 0 {
+		return nil, errors.New("No data found")
+	}
+
+	c := response.CurrentCondition[0]
+	temp, _ := strconv.ParseFloat(c.TempC, 64)
+	feelsLike, _ := strconv.ParseFloat(c.FeelsLikeC, 64)
+	humidity, _ := strconv.Atoi(c.Humidity)
+	pressure, _ := strconv.Atoi(c.Pressure)
+	clouds, _ := strconv.Atoi(c.CloudCover)
+	visibility, _ := strconv.Atoi(c.Visibility)
+	windSpeed, _ := strconv.ParseFloat(c.WindspeedKm, 64)
+	windDeg, _ := strconv.Atoi(c.WinddirDeg)
+
+	return &WeatherObservation{
+		Temperature: temp,
+		FeelsLike:   feelsLike,
+		Humidity:    humidity,
+		Pressure:    pressure,
+		Clouds:      clouds,
+		Visibility:  visibility,
+		WindSpeed:   windSpeed,
+		WindDeg:     windDeg,
+		Icon:        wttrIcon(c.WeatherCode),
+		URL:         requestURL,
+	}, nil
+}
+
+func owmIcon(id string) string {
 	switch id {
 	case "01n":
-		icon = "\ue32b"
+		return "\ue32b"
 	case "01d":
-		icon = "\ue30d"
+		return "\ue30d"
 	case "02n":
-		icon = "\ue37e"
+		return "\ue37e"
 	case "02d":
-		icon = "\ue302"
-	case "03n":
-		fallthrough
-	case "03d":
-		icon = "\ue33d"
-	case "04n":
-		fallthrough
-	case "04d":
-		icon = "\ue312"
-	case "09n":
-		fallthrough
-	case "09d":
-		icon = "\ue319"
+		return "\ue302"
+	case "03n", "03d":
+		return "\ue33d"
+	case "04n", "04d":
+		return "\ue312"
+	case "09n", "09d":
+		return "\ue319"
 	case "10n":
-		icon = "\ue325"
+		return "\ue325"
 	case "10d":
-		icon = "\ue308"
+		return "\ue308"
 	case "11n":
-		icon = "\ue32a"
+		return "\ue32a"
 	case "11d":
-		icon = "\ue30f"
-	case "13n":
-		fallthrough
-	case "13d":
-		icon = "\ue31a"
-	case "50n":
-		fallthrough
-	case "50d":
-		icon = "\ue313"
+		return "\ue30f"
+	case "13n", "13d":
+		return "\ue31a"
+	case "50n", "50d":
+		return "\ue313"
+	}
+	return ""
+}
+
+// metNorwayIcon translates a MET Norway symbol_code into the same Nerd Font
+// glyphs owmIcon uses, so templates don't need to special-case the provider.
+func metNorwayIcon(code string) string {
+	switch code {
+	case "clearsky_day":
+		return owmIcon("01d")
+	case "clearsky_night":
+		return owmIcon("01n")
+	case "fair_day", "partlycloudy_day":
+		return owmIcon("02d")
+	case "fair_night", "partlycloudy_night":
+		return owmIcon("02n")
+	case "cloudy":
+		return owmIcon("03d")
+	case "rainshowers_day", "lightrainshowers_day":
+		return owmIcon("09d")
+	case "rainshowers_night", "lightrainshowers_night":
+		return owmIcon("09n")
+	case "rain", "lightrain", "heavyrain":
+		return owmIcon("10d")
+	case "thunder", "thunderstorm":
+		return owmIcon("11d")
+	case "snow", "lightsnow", "heavysnow", "sleet":
+		return owmIcon("13d")
+	case "fog":
+		return owmIcon("50d")
+	}
+	return ""
+}
+
+// openMeteoIcon translates a WMO weather code into the same Nerd Font glyphs
+// owmIcon uses, so templates don't need to special-case the provider.
+func openMeteoIcon(code int) string {
+	switch {
+	case code == 0:
+		return owmIcon("01d")
+	case code >= 1 && code <= 2:
+		return owmIcon("02d")
+	case code == 3:
+		return owmIcon("04d")
+	case code == 45 || code == 48:
+		return owmIcon("50d")
+	case code >= 51 && code <= 67:
+		return owmIcon("09d")
+	case code >= 71 && code <= 77:
+		return owmIcon("13d")
+	case code >= 80 && code <= 82:
+		return owmIcon("09d")
+	case code >= 95 && code <= 99:
+		return owmIcon("11d")
+	}
+	return ""
+}
+
+// wttrIcon translates a worldweatheronline weather code (used verbatim by
+// wttr.in) into the same Nerd Font glyphs owmIcon uses.
+func wttrIcon(code string) string {
+	switch code {
+	case "113":
+		return owmIcon("01d")
+	case "116":
+		return owmIcon("02d")
+	case "119", "122":
+		return owmIcon("04d")
+	case "143", "248", "260":
+		return owmIcon("50d")
+	case "176", "263", "266", "293", "296", "299", "302", "305", "308", "311", "314", "353", "356", "359":
+		return owmIcon("09d")
+	case "200", "386", "389", "392", "395":
+		return owmIcon("11d")
+	case "179", "227", "230", "317", "320", "323", "326", "329", "332", "335", "338", "365", "368", "371", "374", "377":
+		return owmIcon("13d")
 	}
-	d.Weather = icon
+	return ""
+}
+
+func (d *Owm) setStatus() error {
+	units := d.props.GetString(Units, "standard")
 	d.units = units
+
+	obs, err := d.getProvider().Fetch()
+	if err != nil {
+		return err
+	}
+
+	d.URL = obs.URL
+	d.Temperature = int(math.Round(obs.Temperature))
+	d.Weather = obs.Icon
 	d.UnitIcon = "\ue33e"
 	switch d.units {
 	case "imperial":
-		d.UnitIcon = "°F" // \ue341"
+		d.UnitIcon = "°F" // "
 	case "metric":
-		d.UnitIcon = "°C" // \ue339"
+		d.UnitIcon = "°C" // "
 	case "":
 		fallthrough
 	case "standard":
 		d.UnitIcon = "°K" // <b>K</b>"
 	}
+
+	d.FeelsLike = int(math.Round(obs.FeelsLike))
+	d.TempMin = int(math.Round(obs.TempMin))
+	d.TempMax = int(math.Round(obs.TempMax))
+	d.Humidity = obs.Humidity
+	d.Pressure = obs.Pressure
+	d.WindSpeed = obs.WindSpeed
+	d.WindDeg = obs.WindDeg
+	d.WindDir = windDirection(obs.WindDeg)
+	d.Clouds = obs.Clouds
+	d.Visibility = obs.Visibility
+	d.Sunrise = obs.Sunrise
+	d.Sunset = obs.Sunset
+	d.IsDay = obs.IsDay
+	d.Description = obs.Description
+	d.ShortDescription = obs.ShortDescription
+
+	return nil
+}
+
+// windDirection converts a wind direction in degrees to a 16-point compass direction
+func windDirection(deg int) string {
+	directions := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	idx := int(math.Round(float64(deg)/22.5)) % len(directions)
+	if idx < 0 {
+		idx += len(directions)
+	}
+	return directions[idx]
+}
+
+func (d *Owm) getForecastResult() (*owmForecastResponse, error) {
+	cacheTimeout := d.props.GetInt(properties.CacheTimeout, properties.DefaultCacheTimeout)
+	response := new(owmForecastResponse)
+
+	if cacheTimeout > 0 {
+		val, found := d.env.Cache().Get(CacheKeyForecastResponse)
+		if found {
+			err := json.Unmarshal([]byte(val), response)
+			if err != nil {
+				return nil, err
+			}
+
+			return response, nil
+		}
+	}
+
+	apikey := d.getPropOrEnvVar(PoshOWMAPIKey, "", APIKey, "apiKey")
+	if len(apikey) == 0 {
+		return nil, errors.New("no api key found")
+	}
+
+	units := d.props.GetString(Units, "standard")
+	httpTimeout := d.props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
+
+	lat, lon, err := resolveCoordinates(d.props, d.env, apikey)
+	if err != nil {
+		return nil, err
+	}
+
+	forecastURL := fmt.Sprintf("https://api.openweathermap.org/data/2.5/forecast?lat=%s&lon=%s&units=%s&appid=%s", lat, lon, units, apikey)
+
+	body, err := d.env.HTTPRequest(forecastURL, nil, httpTimeout)
+	if err != nil {
+		return new(owmForecastResponse), err
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return new(owmForecastResponse), err
+	}
+
+	if cacheTimeout > 0 {
+		d.env.Cache().Set(CacheKeyForecastResponse, string(body), cacheTimeout)
+		d.env.Cache().Set(CacheKeyForecastURL, forecastURL, cacheTimeout)
+	}
+	return response, nil
+}
+
+// setForecast aggregates the 3-hour buckets returned by the forecast endpoint
+// into up to `days` daily entries, ordered chronologically.
+func (d *Owm) setForecast(days int) error {
+	q, err := d.getForecastResult()
+	if err != nil {
+		return err
+	}
+
+	if len(q.List) == 0 {
+		return errors.New("No forecast data found")
+	}
+
+	offset := time.Duration(q.City.Timezone) * time.Second
+	order := make([]string, 0)
+	byDay := make(map[string]*OwmForecastDay)
+	iconCount := make(map[string]map[string]int)
+	iconOrder := make(map[string][]string)
+
+	for _, entry := range q.List {
+		local := time.Unix(entry.Dt, 0).UTC().Add(offset)
+		key := local.Format("2006-01-02")
+
+		day, ok := byDay[key]
+		if !ok {
+			day = &OwmForecastDay{
+				Date: time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC),
+				Min:  int(math.Round(entry.Main.TempMin)),
+				Max:  int(math.Round(entry.Main.TempMax)),
+			}
+			byDay[key] = day
+			iconCount[key] = make(map[string]int)
+			order = append(order, key)
+		}
+
+		if min := int(math.Round(entry.Main.TempMin)); min < day.Min {
+			day.Min = min
+		}
+		if max := int(math.Round(entry.Main.TempMax)); max > day.Max {
+			day.Max = max
+		}
+
+		day.PrecipMM += entry.Rain.ThreeHour + entry.Snow.ThreeHour
+
+		if len(entry.Data) > 0 {
+			id := entry.Data[0].TypeID
+			if iconCount[key][id] == 0 {
+				iconOrder[key] = append(iconOrder[key], id)
+			}
+			iconCount[key][id]++
+		}
+	}
+
+	sort.Strings(order)
+
+	forecast := make([]OwmForecastDay, 0, len(order))
+	for _, key := range order {
+		day := byDay[key]
+		day.Weather = owmIcon(dominantIcon(iconCount[key], iconOrder[key]))
+		forecast = append(forecast, *day)
+	}
+
+	if len(forecast) > days {
+		forecast = forecast[:days]
+	}
+
+	d.Forecast = forecast
+	return nil
+}
+
+// dominantIcon returns the icon id with the highest bucket count for a day.
+// Ties are broken by first-seen order (order) rather than map iteration,
+// which Go randomizes, so the same forecast data always picks the same icon.
+func dominantIcon(counts map[string]int, order []string) string {
+	best := ""
+	bestCount := -1
+	for _, id := range order {
+		if count := counts[id]; count > bestCount {
+			best = id
+			bestCount = count
+		}
+	}
+	return best
+}
+
+type owmAirPollutionResponse struct {
+	List []struct {
+		Main struct {
+			AQI int `json:"aqi"`
+		} `json:"main"`
+		Components struct {
+			CO   float64 `json:"co"`
+			NO2  float64 `json:"no2"`
+			O3   float64 `json:"o3"`
+			SO2  float64 `json:"so2"`
+			PM25 float64 `json:"pm2_5"`
+			PM10 float64 `json:"pm10"`
+		} `json:"components"`
+	} `json:"list"`
+}
+
+// aqiLabel translates an OWM AQI index (1-5) into its documented label
+func aqiLabel(aqi int) string {
+	switch aqi {
+	case 1:
+		return "Good"
+	case 2:
+		return "Fair"
+	case 3:
+		return "Moderate"
+	case 4:
+		return "Poor"
+	case 5:
+		return "Very Poor"
+	}
+	return ""
+}
+
+// aqiColor maps an OWM AQI index to a color hint templates can use in
+// foreground_templates to flag hazardous air quality
+func aqiColor(aqi int) string {
+	switch aqi {
+	case 1:
+		return "#2ECC71"
+	case 2:
+		return "#A3CB38"
+	case 3:
+		return "#F1C40F"
+	case 4:
+		return "#E67E22"
+	case 5:
+		return "#E74C3C"
+	}
+	return ""
+}
+
+// setAirQuality fetches the air pollution sub-segment for the same
+// coordinates used for the current weather lookup, cached separately from
+// the weather response so the two can expire independently.
+func (d *Owm) setAirQuality() error {
+	apikey := d.getPropOrEnvVar(PoshOWMAPIKey, "", APIKey, "apiKey")
+	if len(apikey) == 0 {
+		return errors.New("no api key found")
+	}
+
+	lat, lon, err := resolveCoordinates(d.props, d.env, apikey)
+	if err != nil {
+		return err
+	}
+
+	cacheTimeout := d.props.GetInt(properties.CacheTimeout, properties.DefaultCacheTimeout)
+	response := new(owmAirPollutionResponse)
+
+	if cacheTimeout > 0 {
+		val, found := d.env.Cache().Get(CacheKeyAirResponse)
+		if found {
+			if err := json.Unmarshal([]byte(val), response); err != nil {
+				return err
+			}
+
+			return d.setAirQualityFields(response)
+		}
+	}
+
+	requestURL := fmt.Sprintf("https://api.openweathermap.org/data/2.5/air_pollution?lat=%s&lon=%s&appid=%s", lat, lon, apikey)
+	httpTimeout := d.props.GetInt(properties.HTTPTimeout, properties.DefaultHTTPTimeout)
+
+	body, err := d.env.HTTPRequest(requestURL, nil, httpTimeout)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return err
+	}
+
+	if cacheTimeout > 0 {
+		d.env.Cache().Set(CacheKeyAirResponse, string(body), cacheTimeout)
+	}
+
+	return d.setAirQualityFields(response)
+}
+
+func (d *Owm) setAirQualityFields(response *owmAirPollutionResponse) error {
+	if len(response.List) == 0 {
+		return errors.New("No air quality data found")
+	}
+
+	entry := response.List[0]
+	d.AQI = entry.Main.AQI
+	d.AQILabel = aqiLabel(entry.Main.AQI)
+	d.AQIColor = aqiColor(entry.Main.AQI)
+	d.CO = entry.Components.CO
+	d.NO2 = entry.Components.NO2
+	d.O3 = entry.Components.O3
+	d.SO2 = entry.Components.SO2
+	d.PM25 = entry.Components.PM25
+	d.PM10 = entry.Components.PM10
+
 	return nil
 }
 
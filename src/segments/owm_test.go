@@ -0,0 +1,203 @@
+package segments
+
+import (
+	"crypto/md5"
+	"fmt"
+	"testing"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/mock"
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+)
+
+func TestDominantIcon(t *testing.T) {
+	cases := []struct {
+		name   string
+		counts map[string]int
+		order  []string
+		want   string
+	}{
+		{
+			name:   "single candidate",
+			counts: map[string]int{"01d": 3},
+			order:  []string{"01d"},
+			want:   "01d",
+		},
+		{
+			name:   "clear winner",
+			counts: map[string]int{"01d": 1, "10d": 5, "03d": 2},
+			order:  []string{"01d", "10d", "03d"},
+			want:   "10d",
+		},
+		{
+			name:   "tie broken by first-seen order",
+			counts: map[string]int{"01d": 2, "10d": 2},
+			order:  []string{"01d", "10d"},
+			want:   "01d",
+		},
+		{
+			name:   "tie broken by first-seen order, reversed",
+			counts: map[string]int{"01d": 2, "10d": 2},
+			order:  []string{"10d", "01d"},
+			want:   "10d",
+		},
+		{
+			name:   "no data",
+			counts: map[string]int{},
+			order:  nil,
+			want:   "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, dominantIcon(tc.counts, tc.order))
+		})
+	}
+}
+
+func TestWindDirection(t *testing.T) {
+	cases := []struct {
+		deg  int
+		want string
+	}{
+		{0, "N"},
+		{45, "NE"},
+		{90, "E"},
+		{180, "S"},
+		{270, "W"},
+		{360, "N"},
+		{-22, "NNW"},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%ddeg", tc.deg), func(t *testing.T) {
+			assert.Equal(t, tc.want, windDirection(tc.deg))
+		})
+	}
+}
+
+func TestAqiLabel(t *testing.T) {
+	cases := map[int]string{
+		1: "Good",
+		2: "Fair",
+		3: "Moderate",
+		4: "Poor",
+		5: "Very Poor",
+		0: "",
+		6: "",
+	}
+
+	for aqi, want := range cases {
+		assert.Equal(t, want, aqiLabel(aqi))
+	}
+}
+
+func TestAqiColor(t *testing.T) {
+	for aqi := 1; aqi <= 5; aqi++ {
+		assert.NotEmpty(t, aqiColor(aqi))
+	}
+
+	assert.Empty(t, aqiColor(0))
+}
+
+func TestOwmIcon(t *testing.T) {
+	known := []string{"01d", "01n", "02d", "02n", "03d", "04d", "09d", "10d", "10n", "11d", "11n", "13d", "50d"}
+	for _, id := range known {
+		assert.NotEmpty(t, owmIcon(id))
+	}
+
+	assert.Empty(t, owmIcon("unknown"))
+}
+
+func TestMetNorwayIcon(t *testing.T) {
+	assert.Equal(t, owmIcon("01d"), metNorwayIcon("clearsky_day"))
+	assert.Empty(t, metNorwayIcon("unknown_code"))
+}
+
+func TestOpenMeteoIcon(t *testing.T) {
+	assert.Equal(t, owmIcon("01d"), openMeteoIcon(0))
+	assert.Equal(t, owmIcon("11d"), openMeteoIcon(95))
+	assert.Empty(t, openMeteoIcon(-1))
+}
+
+func TestWttrIcon(t *testing.T) {
+	assert.Equal(t, owmIcon("01d"), wttrIcon("113"))
+	assert.Empty(t, wttrIcon("unknown"))
+}
+
+func TestResolveCoordinatesExplicitLatLonBypassesGeocoding(t *testing.T) {
+	props := properties.Map{
+		Latitude:  "52.1",
+		Longitude: "5.18",
+	}
+	env := new(mock.Environment)
+	env.On("Getenv", testifyMock.Anything).Return("")
+
+	lat, lon, err := resolveCoordinates(props, env, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "52.1", lat)
+	assert.Equal(t, "5.18", lon)
+	env.AssertNotCalled(t, "HTTPRequest", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything)
+}
+
+func TestResolveCoordinatesGeocodesAndCaches(t *testing.T) {
+	props := properties.Map{
+		Location: "Amsterdam,NL",
+	}
+
+	cache := new(mock.Cache)
+	cache.On("Get", testifyMock.Anything).Return("", false)
+	cache.On("Set", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything).Return()
+
+	env := new(mock.Environment)
+	env.On("Getenv", testifyMock.Anything).Return("")
+	env.On("Cache").Return(cache)
+	env.On("HTTPRequest", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything).Return([]byte(`[{"lat":52.37,"lon":4.89}]`), nil)
+
+	lat, lon, err := resolveCoordinates(props, env, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "52.37", lat)
+	assert.Equal(t, "4.89", lon)
+	env.AssertNumberOfCalls(t, "HTTPRequest", 1)
+	cache.AssertNumberOfCalls(t, "Set", 1)
+}
+
+func TestResolveCoordinatesCacheHitSkipsRequest(t *testing.T) {
+	props := properties.Map{
+		Location: "Amsterdam,NL",
+	}
+	cacheKey := fmt.Sprintf("%s%x", CacheKeyGeoPrefix, md5.Sum([]byte("Amsterdam,NL")))
+
+	cache := new(mock.Cache)
+	cache.On("Get", cacheKey).Return("52.37,4.89", true)
+
+	env := new(mock.Environment)
+	env.On("Getenv", testifyMock.Anything).Return("")
+	env.On("Cache").Return(cache)
+
+	lat, lon, err := resolveCoordinates(props, env, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "52.37", lat)
+	assert.Equal(t, "4.89", lon)
+	env.AssertNotCalled(t, "HTTPRequest", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything)
+}
+
+func TestResolveCoordinatesZipRejectsNullIsland(t *testing.T) {
+	props := properties.Map{
+		Zip: "00000",
+	}
+
+	cache := new(mock.Cache)
+	cache.On("Get", testifyMock.Anything).Return("", false)
+
+	env := new(mock.Environment)
+	env.On("Getenv", testifyMock.Anything).Return("")
+	env.On("Cache").Return(cache)
+	env.On("HTTPRequest", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything).Return([]byte(`{"lat":0,"lon":0}`), nil)
+
+	_, _, err := resolveCoordinates(props, env, "key")
+	assert.Error(t, err)
+}